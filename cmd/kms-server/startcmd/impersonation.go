@@ -0,0 +1,107 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/trustbloc/edge-core/pkg/utils/cmdutils"
+
+	"github.com/rajeshkalaria80/kms/pkg/httpclient"
+)
+
+const (
+	enableImpersonationFlagName  = "enable-impersonation"
+	enableImpersonationEnvKey    = "KMS_ENABLE_IMPERSONATION"
+	enableImpersonationFlagUsage = "Allow kms-server to act on behalf of an end user against a shared" +
+		" auth-server/EDV backend via delegated-identity headers. Disabled by default." +
+		" Alternatively, this can be set with the " + enableImpersonationEnvKey + " environment variable."
+
+	impersonationAllowedSubjectsFlagName  = "impersonation-allowed-subjects"
+	impersonationAllowedSubjectsEnvKey    = "KMS_IMPERSONATION_ALLOWED_SUBJECTS"
+	impersonationAllowedSubjectsFlagUsage = "Comma-separated allowlist of subjects kms-server is permitted to" +
+		" impersonate. Required when --" + enableImpersonationFlagName + " is set." +
+		" Alternatively, this can be set with the " + impersonationAllowedSubjectsEnvKey + " environment variable."
+)
+
+// impersonationParameters holds the impersonation gate/allowlist flag values.
+type impersonationParameters struct {
+	enabled         bool
+	allowedSubjects map[string]bool
+}
+
+func addImpersonationFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(enableImpersonationFlagName, "", "", enableImpersonationFlagUsage)
+	cmd.Flags().StringP(impersonationAllowedSubjectsFlagName, "", "", impersonationAllowedSubjectsFlagUsage)
+}
+
+func getImpersonationParameters(cmd *cobra.Command) (*impersonationParameters, error) {
+	enabled, err := getBoolParameter(cmd, enableImpersonationFlagName, enableImpersonationEnvKey)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedSubjectsStr, err := cmdutils.GetUserSetVarFromString(cmd, impersonationAllowedSubjectsFlagName,
+		impersonationAllowedSubjectsEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedSubjects := map[string]bool{}
+
+	for _, subject := range strings.Split(allowedSubjectsStr, ",") {
+		subject = strings.TrimSpace(subject)
+		if subject != "" {
+			allowedSubjects[subject] = true
+		}
+	}
+
+	if enabled && len(allowedSubjects) == 0 {
+		return nil, fmt.Errorf("--%s is required when --%s is set",
+			impersonationAllowedSubjectsFlagName, enableImpersonationFlagName)
+	}
+
+	return &impersonationParameters{
+		enabled:         enabled,
+		allowedSubjects: allowedSubjects,
+	}, nil
+}
+
+// validateImpersonation rejects an attempt to impersonate sub unless impersonation is enabled
+// and sub is on the configured allowlist.
+func (p *impersonationParameters) validateImpersonation(sub string) error {
+	if sub == "" {
+		return nil
+	}
+
+	if !p.enabled {
+		return fmt.Errorf("impersonation requested for %q but --%s is not set", sub, enableImpersonationFlagName)
+	}
+
+	if !p.allowedSubjects[sub] {
+		return fmt.Errorf("impersonation requested for %q is not in --%s", sub, impersonationAllowedSubjectsFlagName)
+	}
+
+	return nil
+}
+
+// impersonationOpts validates sub against the gate/allowlist and, on success, returns the
+// httpclient.Opts that make outbound calls carry its delegated-identity headers. It returns no
+// options (and no error) when sub is empty, since there is nothing to impersonate.
+func (p *impersonationParameters) impersonationOpts(sub string, extra map[string][]string) ([]httpclient.Opt, error) {
+	if sub == "" {
+		return nil, nil
+	}
+
+	if err := p.validateImpersonation(sub); err != nil {
+		return nil, err
+	}
+
+	return []httpclient.Opt{httpclient.WithImpersonation(sub, extra)}, nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/trustbloc/edge-core/pkg/utils/cmdutils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/rajeshkalaria80/kms/pkg/httpclient"
+)
+
+const (
+	otlpEndpointFlagName  = "otlp-endpoint"
+	otlpEndpointEnvKey    = "KMS_OTLP_ENDPOINT"
+	otlpEndpointFlagUsage = "OTLP collector endpoint used to export traces and metrics for outbound HTTP calls" +
+		" (KMS to auth-server, EDV, etc). Tracing/metrics are disabled when unset." +
+		" Alternatively, this can be set with the " + otlpEndpointEnvKey + " environment variable."
+
+	otlpInsecureFlagName  = "otlp-insecure"
+	otlpInsecureEnvKey    = "KMS_OTLP_INSECURE"
+	otlpInsecureFlagUsage = "Disable TLS when connecting to the OTLP collector." +
+		" Alternatively, this can be set with the " + otlpInsecureEnvKey + " environment variable."
+
+	otlpHeadersFlagName  = "otlp-headers"
+	otlpHeadersEnvKey    = "KMS_OTLP_HEADERS"
+	otlpHeadersFlagUsage = "Comma-separated key=value headers sent with every OTLP export request" +
+		" (e.g. for collector authentication)." +
+		" Alternatively, this can be set with the " + otlpHeadersEnvKey + " environment variable."
+)
+
+// otelParameters holds the OTLP exporter flag values, plus the httpclient.Opts initOTel computes
+// once the tracer/meter providers are registered.
+type otelParameters struct {
+	endpoint string
+	insecure bool
+	headers  string
+
+	tracingOpts []httpclient.Opt
+}
+
+func addOTelFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(otlpEndpointFlagName, "", "", otlpEndpointFlagUsage)
+	cmd.Flags().StringP(otlpInsecureFlagName, "", "", otlpInsecureFlagUsage)
+	cmd.Flags().StringP(otlpHeadersFlagName, "", "", otlpHeadersFlagUsage)
+}
+
+func getOTelParameters(cmd *cobra.Command) (*otelParameters, error) {
+	endpoint, err := cmdutils.GetUserSetVarFromString(cmd, otlpEndpointFlagName, otlpEndpointEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint == "" {
+		return &otelParameters{}, nil
+	}
+
+	insecure, err := getBoolParameter(cmd, otlpInsecureFlagName, otlpInsecureEnvKey)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := cmdutils.GetUserSetVarFromString(cmd, otlpHeadersFlagName, otlpHeadersEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &otelParameters{
+		endpoint: endpoint,
+		insecure: insecure,
+		headers:  headers,
+	}, nil
+}
+
+// initOTel initializes OTLP gRPC trace and metric exporters from params, registers them as the
+// global OpenTelemetry providers, and caches the resulting httpclient.Opts (including the
+// WithMeter histogram instruments, which httpclient creates once per call to WithMeter) on params
+// so outboundTracingOpts can hand back the same Opts on every outbound call instead of
+// reconstructing them - and the histograms they wrap - per call. It is a no-op when
+// params.endpoint is empty.
+func initOTel(ctx context.Context, params *otelParameters) error {
+	if params.endpoint == "" {
+		return nil
+	}
+
+	traceOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(params.endpoint)}
+	metricOpts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(params.endpoint)}
+
+	if params.insecure {
+		traceOpts = append(traceOpts, otlptracegrpc.WithInsecure())
+		metricOpts = append(metricOpts, otlpmetricgrpc.WithInsecure())
+	}
+
+	if headers := parseOTLPHeaders(params.headers); len(headers) > 0 {
+		traceOpts = append(traceOpts, otlptracegrpc.WithHeaders(headers))
+		metricOpts = append(metricOpts, otlpmetricgrpc.WithHeaders(headers))
+	}
+
+	traceExporter, err := otlptracegrpc.New(ctx, traceOpts...)
+	if err != nil {
+		return fmt.Errorf("new otlp trace exporter: %w", err)
+	}
+
+	metricExporter, err := otlpmetricgrpc.New(ctx, metricOpts...)
+	if err != nil {
+		return fmt.Errorf("new otlp metric exporter: %w", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(traceExporter))
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)))
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+
+	params.tracingOpts = []httpclient.Opt{
+		httpclient.WithTracer(tracerProvider),
+		httpclient.WithMeter(meterProvider),
+	}
+
+	return nil
+}
+
+// outboundTracingOpts returns the httpclient.Opts initOTel computed for the tracer/meter providers
+// it registered, or none when params.endpoint was empty (tracing/metrics disabled) or initOTel has
+// not run yet.
+func outboundTracingOpts(params *otelParameters) []httpclient.Opt {
+	return params.tracingOpts
+}
+
+func parseOTLPHeaders(raw string) map[string]string {
+	if raw == "" {
+		return nil
+	}
+
+	headers := map[string]string{}
+
+	for _, pair := range strings.Split(raw, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return headers
+}
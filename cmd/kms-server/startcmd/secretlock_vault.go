@@ -0,0 +1,144 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/trustbloc/edge-core/pkg/utils/cmdutils"
+
+	"github.com/rajeshkalaria80/kms/pkg/secretlock/vault"
+)
+
+const (
+	secretLockTypeVaultOption = "vault"
+
+	secretLockVaultAddressFlagName  = "secret-lock-vault-address"
+	secretLockVaultAddressEnvKey    = "KMS_SECRET_LOCK_VAULT_ADDRESS"
+	secretLockVaultAddressFlagUsage = "Vault address used to reach the Transit secrets engine." +
+		" Alternatively, this can be set with the " + secretLockVaultAddressEnvKey + " environment variable."
+
+	secretLockVaultTokenFlagName  = "secret-lock-vault-token"
+	secretLockVaultTokenEnvKey    = "KMS_SECRET_LOCK_VAULT_TOKEN"
+	secretLockVaultTokenFlagUsage = "Vault token used instead of AppRole login." +
+		" Alternatively, this can be set with the " + secretLockVaultTokenEnvKey + " environment variable."
+
+	secretLockVaultRoleIDFlagName  = "secret-lock-vault-role-id"
+	secretLockVaultRoleIDEnvKey    = "KMS_SECRET_LOCK_VAULT_ROLE_ID"
+	secretLockVaultRoleIDFlagUsage = "Vault AppRole role ID used to log in when no token is supplied." +
+		" Alternatively, this can be set with the " + secretLockVaultRoleIDEnvKey + " environment variable."
+
+	secretLockVaultSecretIDFlagName  = "secret-lock-vault-secret-id"
+	secretLockVaultSecretIDEnvKey    = "KMS_SECRET_LOCK_VAULT_SECRET_ID"
+	secretLockVaultSecretIDFlagUsage = "Vault AppRole secret ID used to log in when no token is supplied." +
+		" Alternatively, this can be set with the " + secretLockVaultSecretIDEnvKey + " environment variable."
+
+	secretLockVaultNamespaceFlagName  = "secret-lock-vault-namespace"
+	secretLockVaultNamespaceEnvKey    = "KMS_SECRET_LOCK_VAULT_NAMESPACE"
+	secretLockVaultNamespaceFlagUsage = "Vault Enterprise namespace (optional)." +
+		" Alternatively, this can be set with the " + secretLockVaultNamespaceEnvKey + " environment variable."
+
+	secretLockVaultTransitMountFlagName  = "secret-lock-vault-transit-mount"
+	secretLockVaultTransitMountEnvKey    = "KMS_SECRET_LOCK_VAULT_TRANSIT_MOUNT"
+	secretLockVaultTransitMountFlagUsage = "Mount path of the Vault Transit secrets engine." +
+		" Alternatively, this can be set with the " + secretLockVaultTransitMountEnvKey + " environment variable."
+
+	secretLockVaultKeyNameFlagName  = "secret-lock-vault-key-name"
+	secretLockVaultKeyNameEnvKey    = "KMS_SECRET_LOCK_VAULT_KEY_NAME"
+	secretLockVaultKeyNameFlagUsage = "Name of the Vault Transit key used to wrap/unwrap the master key." +
+		" Alternatively, this can be set with the " + secretLockVaultKeyNameEnvKey + " environment variable."
+)
+
+// vaultSecretLockParameters holds the Vault Transit secret-lock flag values.
+type vaultSecretLockParameters struct {
+	address      string
+	token        string
+	roleID       string
+	secretID     string
+	namespace    string
+	transitMount string
+	keyName      string
+}
+
+func addVaultSecretLockFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(secretLockVaultAddressFlagName, "", "", secretLockVaultAddressFlagUsage)
+	cmd.Flags().StringP(secretLockVaultTokenFlagName, "", "", secretLockVaultTokenFlagUsage)
+	cmd.Flags().StringP(secretLockVaultRoleIDFlagName, "", "", secretLockVaultRoleIDFlagUsage)
+	cmd.Flags().StringP(secretLockVaultSecretIDFlagName, "", "", secretLockVaultSecretIDFlagUsage)
+	cmd.Flags().StringP(secretLockVaultNamespaceFlagName, "", "", secretLockVaultNamespaceFlagUsage)
+	cmd.Flags().StringP(secretLockVaultTransitMountFlagName, "", "", secretLockVaultTransitMountFlagUsage)
+	cmd.Flags().StringP(secretLockVaultKeyNameFlagName, "", "", secretLockVaultKeyNameFlagUsage)
+}
+
+func getVaultSecretLockParameters(cmd *cobra.Command) (*vaultSecretLockParameters, error) {
+	address, err := cmdutils.GetUserSetVarFromString(cmd, secretLockVaultAddressFlagName,
+		secretLockVaultAddressEnvKey, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s flag: %w", secretLockVaultAddressFlagName, err)
+	}
+
+	token, err := cmdutils.GetUserSetVarFromString(cmd, secretLockVaultTokenFlagName,
+		secretLockVaultTokenEnvKey, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s flag: %w", secretLockVaultTokenFlagName, err)
+	}
+
+	roleID, err := cmdutils.GetUserSetVarFromString(cmd, secretLockVaultRoleIDFlagName,
+		secretLockVaultRoleIDEnvKey, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s flag: %w", secretLockVaultRoleIDFlagName, err)
+	}
+
+	secretID, err := cmdutils.GetUserSetVarFromString(cmd, secretLockVaultSecretIDFlagName,
+		secretLockVaultSecretIDEnvKey, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s flag: %w", secretLockVaultSecretIDFlagName, err)
+	}
+
+	namespace, err := cmdutils.GetUserSetVarFromString(cmd, secretLockVaultNamespaceFlagName,
+		secretLockVaultNamespaceEnvKey, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s flag: %w", secretLockVaultNamespaceFlagName, err)
+	}
+
+	transitMount, err := cmdutils.GetUserSetVarFromString(cmd, secretLockVaultTransitMountFlagName,
+		secretLockVaultTransitMountEnvKey, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s flag: %w", secretLockVaultTransitMountFlagName, err)
+	}
+
+	keyName, err := cmdutils.GetUserSetVarFromString(cmd, secretLockVaultKeyNameFlagName,
+		secretLockVaultKeyNameEnvKey, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s flag: %w", secretLockVaultKeyNameFlagName, err)
+	}
+
+	return &vaultSecretLockParameters{
+		address:      address,
+		token:        token,
+		roleID:       roleID,
+		secretID:     secretID,
+		namespace:    namespace,
+		transitMount: transitMount,
+		keyName:      keyName,
+	}, nil
+}
+
+// createVaultSecretLock builds the secretlock.Service used by the local KMS provider when
+// secretLockTypeFlagName is set to secretLockTypeVaultOption.
+func createVaultSecretLock(params *vaultSecretLockParameters) (*vault.Service, error) {
+	return vault.New(vault.Config{
+		Address:      params.address,
+		Namespace:    params.namespace,
+		TransitMount: params.transitMount,
+		KeyName:      params.keyName,
+		Token:        params.token,
+		RoleID:       params.roleID,
+		SecretID:     params.secretID,
+	})
+}
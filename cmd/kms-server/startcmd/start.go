@@ -0,0 +1,559 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package startcmd implements the "start" command for kms-server: it registers the command's
+// flags, reads them into serverParameters, and boots the server from them.
+package startcmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	mongodbstorage "github.com/hyperledger/aries-framework-go-ext/component/storage/mongodb"
+	ariesmem "github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/local"
+	logspi "github.com/hyperledger/aries-framework-go/spi/log"
+	"github.com/hyperledger/aries-framework-go/spi/secretlock"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"github.com/trustbloc/edge-core/pkg/utils/cmdutils"
+	"github.com/trustbloc/edge-core/pkg/utils/tlsutils"
+
+	"github.com/rajeshkalaria80/kms/pkg/httpclient"
+	"github.com/rajeshkalaria80/kms/pkg/restapi"
+	awssecretlock "github.com/rajeshkalaria80/kms/pkg/secretlock/aws"
+)
+
+const (
+	hostFlagName  = "host"
+	hostEnvKey    = "KMS_HOST"
+	hostFlagUsage = "Host Name:Port. Alternatively, this can be set with the " + hostEnvKey + " environment variable."
+
+	logLevelFlagName  = "log-level"
+	logLevelEnvKey    = "KMS_LOG_LEVEL"
+	logLevelFlagUsage = "Logging level, one of: critical, error, warning, info, debug. Defaults to info." +
+		" Alternatively, this can be set with the " + logLevelEnvKey + " environment variable."
+
+	databaseTypeFlagName  = "database-type"
+	databaseTypeEnvKey    = "KMS_DATABASE_TYPE"
+	databaseTypeFlagUsage = "The type of database to use for storing KMS data. Supported options: " +
+		storageTypeMemOption + ", " + storageTypeMongoDBOption + "." +
+		" Alternatively, this can be set with the " + databaseTypeEnvKey + " environment variable."
+
+	databaseURLFlagName  = "database-url"
+	databaseURLEnvKey    = "KMS_DATABASE_URL"
+	databaseURLFlagUsage = "The URL of the database. Required for all database types except " +
+		storageTypeMemOption + "." +
+		" Alternatively, this can be set with the " + databaseURLEnvKey + " environment variable."
+
+	storageTypeMemOption     = "mem"
+	storageTypeMongoDBOption = "mongodb"
+
+	secretLockTypeFlagName  = "secret-lock-type"
+	secretLockTypeEnvKey    = "KMS_SECRET_LOCK_TYPE"
+	secretLockTypeFlagUsage = "The type of secret lock to use for protecting the local KMS master key." +
+		" Supported options: " + secretLockTypeLocalOption + ", " + secretLockTypeAWSOption + ", " +
+		secretLockTypeVaultOption + "." +
+		" Alternatively, this can be set with the " + secretLockTypeEnvKey + " environment variable."
+
+	secretLockTypeLocalOption = "local"
+	secretLockTypeAWSOption   = "aws"
+
+	secretLockKeyPathFlagName  = "secret-lock-key-path"
+	secretLockKeyPathEnvKey    = "KMS_SECRET_LOCK_KEY_PATH"
+	secretLockKeyPathFlagUsage = "Path to the file with the base64-encoded master key, used when " +
+		secretLockTypeFlagName + " is " + secretLockTypeLocalOption + "." +
+		" Alternatively, this can be set with the " + secretLockKeyPathEnvKey + " environment variable."
+
+	secretLockAWSKeyURIFlagName  = "secret-lock-aws-key-uri"
+	secretLockAWSKeyURIEnvKey    = "KMS_SECRET_LOCK_AWS_KEY_URI"
+	secretLockAWSKeyURIFlagUsage = "AWS KMS key URI (aws-kms://arn:...) used to wrap/unwrap the master key." +
+		" Alternatively, this can be set with the " + secretLockAWSKeyURIEnvKey + " environment variable."
+
+	secretLockAWSAccessKeyFlagName  = "secret-lock-aws-access-key"
+	secretLockAWSAccessKeyEnvKey    = "KMS_SECRET_LOCK_AWS_ACCESS_KEY"
+	secretLockAWSAccessKeyFlagUsage = "AWS access key used to authenticate to AWS KMS." +
+		" Alternatively, this can be set with the " + secretLockAWSAccessKeyEnvKey + " environment variable."
+
+	secretLockAWSSecretKeyFlagName  = "secret-lock-aws-secret-key"
+	secretLockAWSSecretKeyEnvKey    = "KMS_SECRET_LOCK_AWS_SECRET_KEY"
+	secretLockAWSSecretKeyFlagUsage = "AWS secret key used to authenticate to AWS KMS." +
+		" Alternatively, this can be set with the " + secretLockAWSSecretKeyEnvKey + " environment variable."
+
+	tlsSystemCertPoolFlagName  = "tls-systemcertpool"
+	tlsSystemCertPoolEnvKey    = "KMS_TLS_SYSTEMCERTPOOL"
+	tlsSystemCertPoolFlagUsage = "Use the system certificate pool when verifying TLS connections to" +
+		" downstream services, in addition to --" + tlsCACertsFlagName + "." +
+		" Alternatively, this can be set with the " + tlsSystemCertPoolEnvKey + " environment variable."
+
+	tlsCACertsFlagName  = "tls-cacerts"
+	tlsCACertsEnvKey    = "KMS_TLS_CACERTS"
+	tlsCACertsFlagUsage = "Comma-separated paths to CA certificates used when verifying TLS connections to" +
+		" downstream services." +
+		" Alternatively, this can be set with the " + tlsCACertsEnvKey + " environment variable."
+
+	authServerURLFlagName  = "hub-auth-url"
+	authServerURLEnvKey    = "KMS_HUB_AUTH_URL"
+	authServerURLFlagUsage = "URL of the auth server used to look up user/client profiles." +
+		" Alternatively, this can be set with the " + authServerURLEnvKey + " environment variable."
+
+	enableCORSFlagName  = "enable-cors"
+	enableCORSEnvKey    = "KMS_ENABLE_CORS"
+	enableCORSFlagUsage = "Enable CORS on the kms-server endpoints. Disabled by default." +
+		" Alternatively, this can be set with the " + enableCORSEnvKey + " environment variable."
+
+	enableCacheFlagName  = "enable-cache"
+	enableCacheEnvKey    = "KMS_ENABLE_CACHE"
+	enableCacheFlagUsage = "Enable caching of key store and KMS metadata. Disabled by default." +
+		" Alternatively, this can be set with the " + enableCacheEnvKey + " environment variable."
+
+	keyStoreCacheTTLFlagName  = "key-store-cache-ttl"
+	keyStoreCacheTTLEnvKey    = "KMS_KEY_STORE_CACHE_TTL"
+	keyStoreCacheTTLFlagUsage = "How long a key store lookup is cached for, e.g. \"10m\"." +
+		" Alternatively, this can be set with the " + keyStoreCacheTTLEnvKey + " environment variable."
+
+	kmsCacheTTLFlagName  = "kms-cache-ttl"
+	kmsCacheTTLEnvKey    = "KMS_CACHE_TTL"
+	kmsCacheTTLFlagUsage = "How long KMS metadata is cached for, e.g. \"10m\". Must be greater than zero when" +
+		" --" + enableCacheFlagName + " is set." +
+		" Alternatively, this can be set with the " + kmsCacheTTLEnvKey + " environment variable."
+
+	enableZCAPsFlagName  = "enable-zcaps"
+	enableZCAPsEnvKey    = "KMS_ZCAP_ENABLE"
+	enableZCAPsFlagUsage = "Enable ZCAP-LD authorization on the kms-server endpoints. Disabled by default." +
+		" Alternatively, this can be set with the " + enableZCAPsEnvKey + " environment variable."
+
+	metricsHost = "localhost:8081"
+)
+
+// server abstracts the HTTP server kms-server runs on, so tests can substitute a mock.
+type server interface {
+	ListenAndServe(host, certFile, keyFile string, handler http.Handler) error
+	Logger() logspi.Logger
+}
+
+// HTTPServer is the production server implementation.
+type HTTPServer struct{}
+
+// ListenAndServe starts the server on host, serving over TLS when certFile and keyFile are set.
+func (s *HTTPServer) ListenAndServe(host, certFile, keyFile string, handler http.Handler) error {
+	if certFile != "" && keyFile != "" {
+		return http.ListenAndServeTLS(host, certFile, keyFile, handler)
+	}
+
+	return http.ListenAndServe(host, handler)
+}
+
+// Logger returns the logger used to report unrecoverable server errors.
+func (s *HTTPServer) Logger() logspi.Logger {
+	return log.New("kms-server")
+}
+
+// serverParameters holds all of the parameters needed to start kms-server.
+type serverParameters struct {
+	host              string
+	logLevel          string
+	databaseType      string
+	databaseURL       string
+	secretLockType    string
+	secretLockKeyPath string
+	awsSecretLock     *awsSecretLockParameters
+	vaultSecretLock   *vaultSecretLockParameters
+	tlsSystemCertPool bool
+	tlsCACerts        []string
+	authServerURL     string
+	enableCORS        bool
+	enableCache       bool
+	keyStoreCacheTTL  time.Duration
+	kmsCacheTTL       time.Duration
+	enableZCAPs       bool
+	clientTLSAuth     *clientTLSAuthParameters
+	otel              *otelParameters
+	impersonation     *impersonationParameters
+}
+
+// awsSecretLockParameters holds the AWS KMS secret-lock flag values.
+type awsSecretLockParameters struct {
+	keyURI    string
+	accessKey string
+	secretKey string
+}
+
+// Cmd returns the cobra command that starts kms-server on srv.
+func Cmd(srv server) (*cobra.Command, error) {
+	startCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Starts kms-server",
+		Long:  "Starts server for handling key management and crypto operations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params, err := getParameters(cmd)
+			if err != nil {
+				return fmt.Errorf("get parameters: %w", err)
+			}
+
+			return startServer(srv, params)
+		},
+	}
+
+	addFlags(startCmd)
+
+	return startCmd, nil
+}
+
+func addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(hostFlagName, "", "", hostFlagUsage)
+	cmd.Flags().StringP(logLevelFlagName, "", "", logLevelFlagUsage)
+	cmd.Flags().StringP(databaseTypeFlagName, "", "", databaseTypeFlagUsage)
+	cmd.Flags().StringP(databaseURLFlagName, "", "", databaseURLFlagUsage)
+	cmd.Flags().StringP(secretLockTypeFlagName, "", "", secretLockTypeFlagUsage)
+	cmd.Flags().StringP(secretLockKeyPathFlagName, "", "", secretLockKeyPathFlagUsage)
+	cmd.Flags().StringP(secretLockAWSKeyURIFlagName, "", "", secretLockAWSKeyURIFlagUsage)
+	cmd.Flags().StringP(secretLockAWSAccessKeyFlagName, "", "", secretLockAWSAccessKeyFlagUsage)
+	cmd.Flags().StringP(secretLockAWSSecretKeyFlagName, "", "", secretLockAWSSecretKeyFlagUsage)
+	cmd.Flags().StringP(tlsSystemCertPoolFlagName, "", "", tlsSystemCertPoolFlagUsage)
+	cmd.Flags().StringP(tlsCACertsFlagName, "", "", tlsCACertsFlagUsage)
+	cmd.Flags().StringP(authServerURLFlagName, "", "", authServerURLFlagUsage)
+	cmd.Flags().StringP(enableCORSFlagName, "", "", enableCORSFlagUsage)
+	cmd.Flags().StringP(enableCacheFlagName, "", "", enableCacheFlagUsage)
+	cmd.Flags().StringP(keyStoreCacheTTLFlagName, "", "", keyStoreCacheTTLFlagUsage)
+	cmd.Flags().StringP(kmsCacheTTLFlagName, "", "", kmsCacheTTLFlagUsage)
+	cmd.Flags().StringP(enableZCAPsFlagName, "", "", enableZCAPsFlagUsage)
+
+	addVaultSecretLockFlags(cmd)
+	addClientTLSAuthFlags(cmd)
+	addOTelFlags(cmd)
+	addImpersonationFlags(cmd)
+}
+
+func getParameters(cmd *cobra.Command) (*serverParameters, error) { //nolint:funlen
+	databaseType, err := cmdutils.GetUserSetVarFromString(cmd, databaseTypeFlagName, databaseTypeEnvKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	databaseURL, err := cmdutils.GetUserSetVarFromString(cmd, databaseURLFlagName, databaseURLEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	secretLockType, err := cmdutils.GetUserSetVarFromString(cmd, secretLockTypeFlagName, secretLockTypeEnvKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := cmdutils.GetUserSetVarFromString(cmd, hostFlagName, hostEnvKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	logLevel, err := cmdutils.GetUserSetVarFromString(cmd, logLevelFlagName, logLevelEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	secretLockKeyPath, err := cmdutils.GetUserSetVarFromString(cmd, secretLockKeyPathFlagName,
+		secretLockKeyPathEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	awsSecretLock, err := getAWSSecretLockParameters(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var vaultSecretLock *vaultSecretLockParameters
+
+	if secretLockType == secretLockTypeVaultOption {
+		vaultSecretLock, err = getVaultSecretLockParameters(cmd)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tlsSystemCertPool, tlsCACerts, err := getTLSTrustParameters(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	authServerURL, err := cmdutils.GetUserSetVarFromString(cmd, authServerURLFlagName, authServerURLEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	enableCORS, err := getBoolParameter(cmd, enableCORSFlagName, enableCORSEnvKey)
+	if err != nil {
+		return nil, err
+	}
+
+	enableCache, err := getBoolParameter(cmd, enableCacheFlagName, enableCacheEnvKey)
+	if err != nil {
+		return nil, err
+	}
+
+	enableZCAPs, err := getBoolParameter(cmd, enableZCAPsFlagName, enableZCAPsEnvKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyStoreCacheTTL, err := getDurationParameter(cmd, keyStoreCacheTTLFlagName, keyStoreCacheTTLEnvKey)
+	if err != nil {
+		return nil, err
+	}
+
+	kmsCacheTTL, err := getDurationParameter(cmd, kmsCacheTTLFlagName, kmsCacheTTLEnvKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if enableCache && kmsCacheTTL <= 0 {
+		return nil, fmt.Errorf("%s must be greater than zero when --%s is set", kmsCacheTTLFlagName, enableCacheFlagName)
+	}
+
+	clientTLSAuth, err := getClientTLSAuthParameters(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	otelParams, err := getOTelParameters(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	impersonation, err := getImpersonationParameters(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &serverParameters{
+		host:              host,
+		logLevel:          logLevel,
+		databaseType:      databaseType,
+		databaseURL:       databaseURL,
+		secretLockType:    secretLockType,
+		secretLockKeyPath: secretLockKeyPath,
+		awsSecretLock:     awsSecretLock,
+		vaultSecretLock:   vaultSecretLock,
+		tlsSystemCertPool: tlsSystemCertPool,
+		tlsCACerts:        tlsCACerts,
+		authServerURL:     authServerURL,
+		enableCORS:        enableCORS,
+		enableCache:       enableCache,
+		keyStoreCacheTTL:  keyStoreCacheTTL,
+		kmsCacheTTL:       kmsCacheTTL,
+		enableZCAPs:       enableZCAPs,
+		clientTLSAuth:     clientTLSAuth,
+		otel:              otelParams,
+		impersonation:     impersonation,
+	}, nil
+}
+
+func getAWSSecretLockParameters(cmd *cobra.Command) (*awsSecretLockParameters, error) {
+	keyURI, err := cmdutils.GetUserSetVarFromString(cmd, secretLockAWSKeyURIFlagName, secretLockAWSKeyURIEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	accessKey, err := cmdutils.GetUserSetVarFromString(cmd, secretLockAWSAccessKeyFlagName,
+		secretLockAWSAccessKeyEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	secretKey, err := cmdutils.GetUserSetVarFromString(cmd, secretLockAWSSecretKeyFlagName,
+		secretLockAWSSecretKeyEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &awsSecretLockParameters{keyURI: keyURI, accessKey: accessKey, secretKey: secretKey}, nil
+}
+
+func getTLSTrustParameters(cmd *cobra.Command) (bool, []string, error) {
+	tlsSystemCertPool, err := getBoolParameter(cmd, tlsSystemCertPoolFlagName, tlsSystemCertPoolEnvKey)
+	if err != nil {
+		return false, nil, err
+	}
+
+	tlsCACertsStr, err := cmdutils.GetUserSetVarFromString(cmd, tlsCACertsFlagName, tlsCACertsEnvKey, true)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var tlsCACerts []string
+
+	if tlsCACertsStr != "" {
+		tlsCACerts = strings.Split(tlsCACertsStr, ",")
+	}
+
+	// GetCertPool is called here, rather than deferred to startServer, purely to surface a bad
+	// --tls-cacerts path as a "get parameters" error instead of a server-start error.
+	if _, err := tlsutils.GetCertPool(tlsSystemCertPool, tlsCACerts); err != nil {
+		return false, nil, err
+	}
+
+	return tlsSystemCertPool, tlsCACerts, nil
+}
+
+func getBoolParameter(cmd *cobra.Command, flagName, envKey string) (bool, error) {
+	val, err := cmdutils.GetUserSetVarFromString(cmd, flagName, envKey, true)
+	if err != nil {
+		return false, err
+	}
+
+	if val == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid --%s: %w", flagName, err)
+	}
+
+	return parsed, nil
+}
+
+func getDurationParameter(cmd *cobra.Command, flagName, envKey string) (time.Duration, error) {
+	val, err := cmdutils.GetUserSetVarFromString(cmd, flagName, envKey, true)
+	if err != nil {
+		return 0, err
+	}
+
+	if val == "" {
+		return 0, nil
+	}
+
+	parsed, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --%s: %w", flagName, err)
+	}
+
+	return parsed, nil
+}
+
+func startServer(srv server, params *serverParameters) error {
+	setLogLevel(params.logLevel)
+
+	storeProvider, err := newStorageProvider(params)
+	if err != nil {
+		return fmt.Errorf("create storage provider: %w", err)
+	}
+
+	secLock, err := newSecretLock(params)
+	if err != nil {
+		return fmt.Errorf("create secret lock: %w", err)
+	}
+
+	if err := initOTel(context.Background(), params.otel); err != nil {
+		return fmt.Errorf("init otel: %w", err)
+	}
+
+	restHandler, err := restapi.New(&restapi.Config{
+		StorageProvider:  storeProvider,
+		SecretLock:       secLock,
+		AuthServerURL:    params.authServerURL,
+		OutboundCallOpts: params.outboundCallOpts,
+		EnableCORS:       params.enableCORS,
+		EnableCache:      params.enableCache,
+		KeyStoreCacheTTL: params.keyStoreCacheTTL,
+		KMSCacheTTL:      params.kmsCacheTTL,
+		EnableZCAPs:      params.enableZCAPs,
+	})
+	if err != nil {
+		return fmt.Errorf("create rest handler: %w", err)
+	}
+
+	startMetrics(srv, metricsHost)
+
+	return srv.ListenAndServe(params.host, "", "", restHandler)
+}
+
+func setLogLevel(levelStr string) {
+	level, err := log.ParseLevel(levelStr)
+	if err != nil {
+		level = logspi.INFO
+	}
+
+	log.SetLevel("", level)
+}
+
+func newStorageProvider(params *serverParameters) (storage.Provider, error) {
+	switch params.databaseType {
+	case storageTypeMemOption:
+		return ariesmem.NewProvider(), nil
+	case storageTypeMongoDBOption:
+		return mongodbstorage.NewProvider(params.databaseURL)
+	default:
+		return nil, fmt.Errorf("database type not supported: %s", params.databaseType)
+	}
+}
+
+// newSecretLock builds the secretlock.Service protecting the local KMS master key, per
+// --secret-lock-type. The vault option delegates to createVaultSecretLock, the Vault Transit
+// backend added alongside the local and aws options.
+func newSecretLock(params *serverParameters) (secretlock.Service, error) {
+	switch params.secretLockType {
+	case secretLockTypeLocalOption:
+		return newLocalSecretLock(params.secretLockKeyPath)
+	case secretLockTypeAWSOption:
+		return awssecretlock.New(awssecretlock.Config{
+			KeyURI:    params.awsSecretLock.keyURI,
+			AccessKey: params.awsSecretLock.accessKey,
+			SecretKey: params.awsSecretLock.secretKey,
+		})
+	case secretLockTypeVaultOption:
+		return createVaultSecretLock(params.vaultSecretLock)
+	default:
+		return nil, fmt.Errorf("secret lock type not supported: %s", params.secretLockType)
+	}
+}
+
+func newLocalSecretLock(keyPath string) (secretlock.Service, error) {
+	keyFile, err := os.Open(keyPath) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer keyFile.Close() //nolint:errcheck
+
+	return local.NewService(keyFile, nil)
+}
+
+// outboundCallOpts returns the httpclient.Opts that every call kms-server makes to the
+// auth-server, EDV, or cross-cluster KMS peers should be made with: the configured mTLS client
+// certificate, the OpenTelemetry tracer/meter, and, when sub is set, delegated-identity
+// impersonation headers for sub once validated against the --impersonation-allowed-subjects gate.
+func (p *serverParameters) outboundCallOpts(sub string, extra map[string][]string) ([]httpclient.Opt, error) {
+	opts, err := p.clientTLSAuth.outboundHTTPOpts()
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, outboundTracingOpts(p.otel)...)
+
+	impersonationOpts, err := p.impersonation.impersonationOpts(sub, extra)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(opts, impersonationOpts...), nil
+}
+
+// startMetrics serves Prometheus metrics on addr in the background. If the metrics server fails
+// to bind or stops, the error is reported through srv.Logger().Fatalf.
+func startMetrics(srv server, addr string) {
+	go func() {
+		if err := http.ListenAndServe(addr, promhttp.Handler()); err != nil { //nolint:gosec
+			srv.Logger().Fatalf("metrics server closed unexpectedly: %s", err)
+		}
+	}()
+}
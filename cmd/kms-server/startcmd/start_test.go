@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package startcmd //nolint:testpackage
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -318,6 +319,176 @@ func TestStartCmdWithAWSSecretLockParam(t *testing.T) {
 	})
 }
 
+func TestStartCmdWithVaultSecretLockParam(t *testing.T) {
+	t.Run("Fail with vault address not set", func(t *testing.T) {
+		startCmd, err := Cmd(&mockServer{})
+		require.NoError(t, err)
+
+		args := requiredArgsWithLockType(storageTypeMemOption, secretLockTypeVaultOption)
+		args = append(args, "--"+secretLockVaultTransitMountFlagName, "transit",
+			"--"+secretLockVaultKeyNameFlagName, "kms-master-key")
+
+		startCmd.SetArgs(args)
+
+		err = startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), secretLockVaultAddressFlagName)
+	})
+
+	t.Run("Success with vault address and token configured", func(t *testing.T) {
+		startCmd, err := Cmd(&mockServer{})
+		require.NoError(t, err)
+
+		// A Vault token (rather than AppRole credentials) is supplied, so New only logs a
+		// failed lookup-self against this unreachable address instead of failing outright -
+		// background renewal is simply disabled. See vault.Service's token-login path.
+		args := requiredArgsWithLockType(storageTypeMemOption, secretLockTypeVaultOption)
+		args = append(args, "--"+secretLockVaultAddressFlagName, "http://127.0.0.1:0",
+			"--"+secretLockVaultTokenFlagName, "test-token",
+			"--"+secretLockVaultTransitMountFlagName, "transit",
+			"--"+secretLockVaultKeyNameFlagName, "kms-master-key")
+
+		startCmd.SetArgs(args)
+
+		err = startCmd.Execute()
+		require.NoError(t, err)
+	})
+}
+
+func TestStartCmdWithClientTLSAuthParam(t *testing.T) {
+	t.Run("Success with no client TLS cert configured", func(t *testing.T) {
+		startCmd, err := Cmd(&mockServer{})
+		require.NoError(t, err)
+
+		startCmd.SetArgs(requiredArgs(storageTypeMemOption))
+
+		err = startCmd.Execute()
+		require.NoError(t, err)
+	})
+
+	t.Run("client-tls-cert flag reaches outbound call options", func(t *testing.T) {
+		// The client certificate is loaded lazily (on first outbound request, via
+		// httpclient.WithClientCertificateFromFiles) rather than at startup, so Execute
+		// itself can't observe a bad cert path - assert on outboundCallOpts instead, the
+		// same function the auth-server/EDV HTTP client is built from.
+		startCmd, err := Cmd(&mockServer{})
+		require.NoError(t, err)
+
+		args := requiredArgs(storageTypeMemOption)
+		args = append(args, "--"+clientTLSCertFlagName, "/test/client.crt",
+			"--"+clientTLSKeyFlagName, "/test/client.key")
+
+		require.NoError(t, startCmd.ParseFlags(args))
+
+		params, err := getParameters(startCmd)
+		require.NoError(t, err)
+		require.Equal(t, "/test/client.crt", params.clientTLSAuth.certPath)
+
+		opts, err := params.outboundCallOpts("", nil)
+		require.NoError(t, err)
+		require.Len(t, opts, 1)
+	})
+}
+
+func TestStartCmdWithOTelParam(t *testing.T) {
+	t.Run("Success with no otlp-endpoint configured", func(t *testing.T) {
+		startCmd, err := Cmd(&mockServer{})
+		require.NoError(t, err)
+
+		startCmd.SetArgs(requiredArgs(storageTypeMemOption))
+
+		err = startCmd.Execute()
+		require.NoError(t, err)
+	})
+
+	t.Run("Success with otlp-endpoint configured", func(t *testing.T) {
+		startCmd, err := Cmd(&mockServer{})
+		require.NoError(t, err)
+
+		args := requiredArgs(storageTypeMemOption)
+		args = append(args, "--"+otlpEndpointFlagName, "127.0.0.1:4317",
+			"--"+otlpInsecureFlagName, "true")
+
+		startCmd.SetArgs(args)
+
+		// The OTLP gRPC exporters connect lazily, so Execute succeeds even against an
+		// endpoint with nothing listening.
+		err = startCmd.Execute()
+		require.NoError(t, err)
+	})
+
+	t.Run("otlp-endpoint flag reaches outbound call options", func(t *testing.T) {
+		startCmd, err := Cmd(&mockServer{})
+		require.NoError(t, err)
+
+		args := requiredArgs(storageTypeMemOption)
+		args = append(args, "--"+otlpEndpointFlagName, "127.0.0.1:4317",
+			"--"+otlpInsecureFlagName, "true")
+
+		require.NoError(t, startCmd.ParseFlags(args))
+
+		params, err := getParameters(startCmd)
+		require.NoError(t, err)
+		require.Equal(t, "127.0.0.1:4317", params.otel.endpoint)
+
+		require.NoError(t, initOTel(context.Background(), params.otel))
+
+		opts, err := params.outboundCallOpts("", nil)
+		require.NoError(t, err)
+		require.Len(t, opts, 2)
+
+		// Calling outboundCallOpts again must reuse the same cached tracingOpts rather than
+		// re-registering a new histogram instrument against the meter provider.
+		optsAgain, err := params.outboundCallOpts("", nil)
+		require.NoError(t, err)
+		require.Len(t, optsAgain, 2)
+	})
+}
+
+func TestStartCmdWithImpersonationParam(t *testing.T) {
+	t.Run("Success with impersonation disabled and no sub requested", func(t *testing.T) {
+		startCmd, err := Cmd(&mockServer{})
+		require.NoError(t, err)
+
+		startCmd.SetArgs(requiredArgs(storageTypeMemOption))
+
+		err = startCmd.Execute()
+		require.NoError(t, err)
+	})
+
+	t.Run("outboundCallOpts rejects impersonation when not enabled", func(t *testing.T) {
+		startCmd, err := Cmd(&mockServer{})
+		require.NoError(t, err)
+
+		require.NoError(t, startCmd.ParseFlags(requiredArgs(storageTypeMemOption)))
+
+		params, err := getParameters(startCmd)
+		require.NoError(t, err)
+
+		_, err = params.outboundCallOpts("user1", nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), enableImpersonationFlagName)
+	})
+
+	t.Run("enable-impersonation and allowed-subjects flags reach outbound call options", func(t *testing.T) {
+		startCmd, err := Cmd(&mockServer{})
+		require.NoError(t, err)
+
+		args := requiredArgs(storageTypeMemOption)
+		args = append(args, "--"+enableImpersonationFlagName, "true",
+			"--"+impersonationAllowedSubjectsFlagName, "user1,user2")
+
+		require.NoError(t, startCmd.ParseFlags(args))
+
+		params, err := getParameters(startCmd)
+		require.NoError(t, err)
+
+		opts, err := params.outboundCallOpts("user1", nil)
+		require.NoError(t, err)
+		require.Len(t, opts, 1)
+	})
+}
+
 func TestStartCmdWithHubAuthURLParam(t *testing.T) {
 	startCmd, err := Cmd(&mockServer{})
 	require.NoError(t, err)
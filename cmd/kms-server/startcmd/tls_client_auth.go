@@ -0,0 +1,105 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/trustbloc/edge-core/pkg/utils/cmdutils"
+
+	"github.com/rajeshkalaria80/kms/pkg/httpclient"
+)
+
+const (
+	clientTLSCertFlagName  = "client-tls-cert"
+	clientTLSCertEnvKey    = "KMS_CLIENT_TLS_CERT"
+	clientTLSCertFlagUsage = "Path to the client TLS certificate used to authenticate outbound calls" +
+		" (e.g. to the auth server, EDV, or cross-cluster KMS peers) via mTLS." +
+		" Alternatively, this can be set with the " + clientTLSCertEnvKey + " environment variable."
+
+	clientTLSKeyFlagName  = "client-tls-key"
+	clientTLSKeyEnvKey    = "KMS_CLIENT_TLS_KEY"
+	clientTLSKeyFlagUsage = "Path to the private key matching --" + clientTLSCertFlagName + "." +
+		" Alternatively, this can be set with the " + clientTLSKeyEnvKey + " environment variable." +
+		" The key pair is reloaded on SIGHUP so short-lived issued certificates can be rotated without a restart."
+
+	clientTLSCAFlagName  = "client-tls-ca"
+	clientTLSCAEnvKey    = "KMS_CLIENT_TLS_CA"
+	clientTLSCAFlagUsage = "Path to a CA bundle used to verify the server certificate on outbound calls." +
+		" Alternatively, this can be set with the " + clientTLSCAEnvKey + " environment variable."
+)
+
+// clientTLSAuthParameters holds the outbound mTLS flag values.
+type clientTLSAuthParameters struct {
+	certPath string
+	keyPath  string
+	caPath   string
+}
+
+func addClientTLSAuthFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(clientTLSCertFlagName, "", "", clientTLSCertFlagUsage)
+	cmd.Flags().StringP(clientTLSKeyFlagName, "", "", clientTLSKeyFlagUsage)
+	cmd.Flags().StringP(clientTLSCAFlagName, "", "", clientTLSCAFlagUsage)
+}
+
+func getClientTLSAuthParameters(cmd *cobra.Command) (*clientTLSAuthParameters, error) {
+	certPath, err := cmdutils.GetUserSetVarFromString(cmd, clientTLSCertFlagName, clientTLSCertEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPath, err := cmdutils.GetUserSetVarFromString(cmd, clientTLSKeyFlagName, clientTLSKeyEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	caPath, err := cmdutils.GetUserSetVarFromString(cmd, clientTLSCAFlagName, clientTLSCAEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientTLSAuthParameters{certPath: certPath, keyPath: keyPath, caPath: caPath}, nil
+}
+
+// outboundHTTPOpts returns the httpclient.Opts needed to authenticate outbound calls (to the
+// auth server, EDV, cross-cluster KMS peers) with the configured client certificate/CA bundle.
+// It returns no options when no client certificate was configured.
+func (p *clientTLSAuthParameters) outboundHTTPOpts() ([]httpclient.Opt, error) {
+	if p.certPath == "" {
+		return nil, nil
+	}
+
+	opts := []httpclient.Opt{httpclient.WithClientCertificateFromFiles(p.certPath, p.keyPath)}
+
+	if p.caPath != "" {
+		pool, err := loadCAPool(p.caPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cert: %w", err)
+		}
+
+		opts = append(opts, httpclient.WithRootCAs(pool))
+	}
+
+	return opts, nil
+}
+
+func loadCAPool(caPath string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no valid certificates found in %s", caPath)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,282 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package httpclient is kms-server's outbound HTTP client: it signs, retries, traces, and
+// authenticates calls to downstream services (auth-server, EDV, cross-cluster KMS peers, and
+// AWS-fronted endpoints).
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	contentType     = "Content-Type"
+	applicationJSON = "application/json"
+	authorization   = "Authorization"
+)
+
+var logger = log.New("kms-httpclient")
+
+// Response is an HTTP response.
+type Response struct {
+	Status       string
+	StatusCode   int
+	Body         []byte
+	ErrorMessage string
+	// Attempts is the number of HTTP attempts made, including the final one. It is 1 unless
+	// WithRetry was used.
+	Attempts int
+	// LastStatus is the HTTP status of the final attempt, if one was received.
+	LastStatus string
+	// RetryAfter is the Retry-After header of the last response, if any.
+	RetryAfter string
+}
+
+// DoRequest makes an HTTP request, retrying according to WithRetry/WithRetryOn if configured.
+func DoRequest(ctx context.Context, url string, opts ...Opt) (*Response, error) { //nolint:funlen
+	op := &options{
+		httpClient: http.DefaultClient,
+		method:     http.MethodGet,
+	}
+
+	for _, fn := range opts {
+		fn(op)
+	}
+
+	if op.clientCertErr != nil {
+		return nil, op.clientCertErr
+	}
+
+	// Catches a client certificate set before WithHTTPClient in opts, which the certificate
+	// options themselves could not see yet.
+	if op.tlsConfigVal != nil && op.httpClientTLSConfigSet &&
+		(len(op.tlsConfigVal.Certificates) > 0 || op.tlsConfigVal.GetClientCertificate != nil) {
+		return nil, errors.New("with client certificate: caller-supplied http client already sets TLSClientConfig")
+	}
+
+	if op.tlsConfigVal != nil && !op.httpClientTLSConfigSet {
+		transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+		transport.TLSClientConfig = op.tlsConfigVal
+
+		client := *op.httpClient
+		client.Transport = transport
+		op.httpClient = &client
+	}
+
+	body, err := io.ReadAll(op.body)
+	if err != nil {
+		return nil, fmt.Errorf("request body: %w", err)
+	}
+
+	maxAttempts := 1
+	if op.retry != nil {
+		maxAttempts = op.retry.maxAttempts
+	}
+
+	shouldRetry := defaultShouldRetry
+	if op.retryOn != nil {
+		shouldRetry = op.retryOn
+	}
+
+	var (
+		r       *Response
+		lastErr error
+	)
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		r, lastErr = withTracing(ctx, op, op.method, url, len(body), func(spanCtx context.Context) (*Response, error) {
+			return doOnce(spanCtx, url, op, body)
+		})
+
+		r.Attempts = attempt
+		r.LastStatus = r.Status
+
+		if attempt == maxAttempts || !shouldRetry(r, lastErr) {
+			return r, lastErr
+		}
+
+		if waitErr := sleepBeforeRetry(ctx, op.retry, attempt); waitErr != nil {
+			return r, waitErr
+		}
+	}
+
+	return r, lastErr
+}
+
+// doOnce performs a single HTTP attempt, (re-)signing the request if a signer is configured
+// so that time-sensitive auth headers (e.g. X-Amz-Date) stay current on retries.
+func doOnce(ctx context.Context, url string, op *options, body []byte) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, op.method, url, bytes.NewReader(body))
+	if err != nil {
+		return &Response{}, fmt.Errorf("new request: %w", err)
+	}
+
+	req.Header.Add(contentType, applicationJSON)
+
+	injectTraceHeaders(ctx, op, req)
+
+	if op.gnapToken != "" {
+		req.Header.Add(authorization, "GNAP "+op.gnapToken)
+	}
+
+	if op.impersonateSub != "" {
+		req.Header.Set(impersonateUserHeader, op.impersonateSub)
+
+		for key, values := range op.impersonateExtra {
+			for _, value := range values {
+				req.Header.Add(impersonateExtraHeaderPrefix+key, value)
+			}
+		}
+	}
+
+	if op.signer != nil {
+		if err = op.signer.Sign(req); err != nil {
+			return &Response{}, fmt.Errorf("sign http request: %w", err)
+		}
+
+		// recreate request with body again as Sign() above consumes the request Body reader.
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := op.httpClient.Do(req)
+	if err != nil {
+		return &Response{}, fmt.Errorf("http do: %w", err)
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			logger.Errorf("Failed to close response body: %s\n", closeErr.Error())
+		}
+	}()
+
+	r := &Response{
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		RetryAfter: resp.Header.Get("Retry-After"),
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return r, fmt.Errorf("read response body: %w", err)
+	}
+
+	if len(respBody) > 0 {
+		r.Body = respBody
+
+		if resp.StatusCode != http.StatusOK {
+			var errResp errorResponse
+
+			if err = json.Unmarshal(respBody, &errResp); err == nil && errResp.Message != "" {
+				r.ErrorMessage = errResp.Message
+
+				return r, errors.New(errResp.Message)
+			}
+
+			return r, errors.New(resp.Status)
+		}
+	}
+
+	return r, nil
+}
+
+type errorResponse struct {
+	Message string `json:"errMessage,omitempty"`
+}
+
+type requestSigner interface {
+	Sign(req *http.Request) error
+}
+
+type options struct {
+	httpClient             *http.Client
+	method                 string
+	headers                []string
+	body                   io.Reader
+	gnapToken              string
+	signer                 requestSigner
+	tlsConfigVal           *tls.Config
+	httpClientTLSConfigSet bool
+	clientCertErr          error
+	retry                  *retryConfig
+	retryOn                func(*Response, error) bool
+	tracerProvider         trace.TracerProvider
+	meterProvider          metric.MeterProvider
+	durationHist           metric.Float64Histogram
+	sizeHist               metric.Int64Histogram
+	impersonateSub         string
+	impersonateExtra       map[string][]string
+}
+
+type retryConfig struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// tlsConfig returns the *tls.Config to be applied to the request's transport, creating one
+// on first use.
+func (o *options) tlsConfig() *tls.Config {
+	if o.tlsConfigVal == nil {
+		o.tlsConfigVal = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return o.tlsConfigVal
+}
+
+// Opt configures HTTP request options.
+type Opt func(*options)
+
+// WithHTTPClient specifies the custom HTTP client.
+func WithHTTPClient(c *http.Client) Opt {
+	return func(o *options) {
+		o.httpClient = c
+
+		if transport, ok := c.Transport.(*http.Transport); ok && transport != nil && transport.TLSClientConfig != nil {
+			o.httpClientTLSConfigSet = true
+		}
+	}
+}
+
+// WithMethod specifies an HTTP method. Default is GET.
+func WithMethod(method string) Opt {
+	return func(o *options) {
+		o.method = method
+	}
+}
+
+// WithBody specifies HTTP request body.
+func WithBody(val []byte) Opt {
+	return func(o *options) {
+		o.body = bytes.NewBuffer(val)
+	}
+}
+
+// WithGNAPToken specifies an authorization GNAP token.
+func WithGNAPToken(token string) Opt {
+	return func(o *options) {
+		o.gnapToken = token
+	}
+}
+
+// WithSigner specifies a request signer for HTTP Signatures.
+func WithSigner(signer requestSigner) Opt {
+	return func(o *options) {
+		o.signer = signer
+	}
+}
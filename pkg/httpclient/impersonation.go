@@ -0,0 +1,24 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+const (
+	impersonateUserHeader        = "X-Impersonate-User"
+	impersonateExtraHeaderPrefix = "X-Impersonate-Extra-"
+)
+
+// WithImpersonation sets delegated-identity headers (mirroring the Kubernetes impersonation
+// convention) so a downstream service can act on behalf of sub instead of the caller's own
+// identity. When a signer is also configured, the impersonation headers are set before Sign is
+// invoked so they are included in the signed/covered component list, binding the impersonation
+// claim to the caller's key.
+func WithImpersonation(sub string, extra map[string][]string) Opt {
+	return func(o *options) {
+		o.impersonateSub = sub
+		o.impersonateExtra = extra
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithImpersonation_SetsDelegatedIdentityHeaders(t *testing.T) {
+	var user string
+
+	var extra http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = r.Header.Get(impersonateUserHeader)
+		extra = r.Header.Clone()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	resp, err := DoRequest(context.Background(), server.URL, WithBody([]byte{}),
+		WithImpersonation("alice", map[string][]string{"group": {"admins", "devs"}}))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.Equal(t, "alice", user)
+	require.Equal(t, []string{"admins", "devs"}, extra.Values(impersonateExtraHeaderPrefix+"group"))
+}
+
+func TestWithImpersonation_HeadersAreSignedWhenSignerConfigured(t *testing.T) {
+	var signedHeaders string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signedHeaders = r.Header.Get(authorization)
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	signer := NewSigV4Signer(SigV4Config{AccessKeyID: "AKID", SecretAccessKey: "secret", Region: "us-east-1", Service: "s3"})
+
+	resp, err := DoRequest(context.Background(), server.URL, WithBody([]byte{}),
+		WithImpersonation("alice", nil), WithSigner(signer))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.Contains(t, signedHeaders, "SignedHeaders=")
+	require.Contains(t, signedHeaders, strings.ToLower(impersonateUserHeader))
+}
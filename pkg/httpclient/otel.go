@@ -0,0 +1,138 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	signerKindGNAP    = "gnap"
+	signerKindSigV4   = "sigv4"
+	signerKindHTTPSig = "http-sig"
+	signerKindNone    = "none"
+
+	tracerName = "kms-server/httpclient"
+)
+
+// WithTracer enables tracing of outbound requests, using tp to start a span per request and
+// propagate W3C traceparent/tracestate headers. tp is used directly by DoRequest; it is not read
+// from the global OpenTelemetry registry, so pass otel.GetTracerProvider() explicitly if that is
+// where the provider was registered.
+func WithTracer(tp trace.TracerProvider) Opt {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithMeter enables recording request duration and payload size histograms via mp. The
+// histograms are created once, when WithMeter is called, and reused for every DoRequest call
+// that applies the returned Opt - not recreated per call. mp is used directly by DoRequest;
+// it is not read from the global OpenTelemetry registry, so pass otel.GetMeterProvider()
+// explicitly if that is where the provider was registered.
+func WithMeter(mp metric.MeterProvider) Opt {
+	meter := mp.Meter(tracerName)
+
+	durationHist, durationErr := meter.Float64Histogram("kms.http.client.duration")
+	sizeHist, sizeErr := meter.Int64Histogram("kms.http.client.request_size")
+
+	return func(o *options) {
+		o.meterProvider = mp
+
+		if durationErr == nil {
+			o.durationHist = durationHist
+		}
+
+		if sizeErr == nil {
+			o.sizeHist = sizeHist
+		}
+	}
+}
+
+// withTracing wraps call with a span (if a tracer provider is configured) and records duration
+// and payload-size metrics (if a meter provider is configured). reqSize is the request body size
+// recorded in the size histogram; call returns the resulting *Response and any error.
+func withTracing(ctx context.Context, op *options, method, url string, reqSize int,
+	call func(ctx context.Context) (*Response, error)) (*Response, error) {
+	start := time.Now()
+
+	if op.tracerProvider != nil {
+		tracer := op.tracerProvider.Tracer(tracerName)
+
+		var span trace.Span
+
+		ctx, span = tracer.Start(ctx, "HTTP "+method)
+		defer span.End()
+	}
+
+	r, err := call(ctx)
+
+	if op.tracerProvider != nil {
+		span := trace.SpanFromContext(ctx)
+		span.SetAttributes(
+			attribute.String("http.method", method),
+			attribute.String("http.url", url),
+			attribute.String("kms.signer", signerKind(op)),
+		)
+
+		if r != nil {
+			span.SetAttributes(attribute.Int("http.status_code", r.StatusCode))
+		}
+
+		if err != nil {
+			span.RecordError(err)
+		}
+	}
+
+	if op.meterProvider != nil {
+		attrs := metric.WithAttributes(
+			attribute.String("http.method", method),
+			attribute.String("kms.signer", signerKind(op)),
+		)
+
+		if op.durationHist != nil {
+			op.durationHist.Record(ctx, time.Since(start).Seconds(), attrs)
+		}
+
+		if op.sizeHist != nil {
+			op.sizeHist.Record(ctx, int64(reqSize), attrs)
+		}
+	}
+
+	return r, err
+}
+
+// injectTraceHeaders propagates the W3C traceparent/tracestate headers from ctx onto req.
+func injectTraceHeaders(ctx context.Context, op *options, req *http.Request) {
+	if op.tracerProvider == nil {
+		return
+	}
+
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
+
+func signerKind(op *options) string {
+	switch {
+	case op.gnapToken != "":
+		return signerKindGNAP
+	case op.signer == nil:
+		return signerKindNone
+	default:
+		if _, ok := op.signer.(*SigV4Signer); ok {
+			return signerKindSigV4
+		}
+
+		return signerKindHTTPSig
+	}
+}
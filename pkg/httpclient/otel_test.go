@@ -0,0 +1,82 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTracer_RecordsSpanAndInjectsTraceparentHeader(t *testing.T) {
+	var traceparent string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	resp, err := DoRequest(context.Background(), server.URL, WithBody([]byte{}), WithTracer(tp))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEmpty(t, traceparent, "traceparent header should have been injected into the outbound request")
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	attrs := attrMap(spans[0].Attributes)
+	require.Equal(t, "GET", attrs["http.method"].AsString())
+	require.Equal(t, int64(http.StatusOK), attrs["http.status_code"].AsInt64())
+}
+
+func TestWithMeter_RecordsDurationAndSizeHistograms(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	meterOpt := WithMeter(mp)
+
+	// DoRequest is called twice with the same pre-built meterOpt, so both calls record into the
+	// single set of histogram instruments WithMeter created above; the Collect below must then
+	// find the recorded measurements.
+	for i := 0; i < 2; i++ {
+		resp, err := DoRequest(context.Background(), server.URL, WithBody([]byte("payload")), meterOpt)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	var rm metricdata.ResourceMetrics
+
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.NotEmpty(t, rm.ScopeMetrics, "expected duration/size histograms to have been recorded")
+}
+
+// attrMap flattens a span's attribute.KeyValue slice into a map keyed by attribute key.
+func attrMap(attrs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(attrs))
+	for _, kv := range attrs {
+		m[kv.Key] = kv.Value
+	}
+
+	return m
+}
@@ -0,0 +1,77 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// defaultShouldRetry retries on network failures, 502/503/504, and 429 with a Retry-After header.
+func defaultShouldRetry(r *Response, err error) bool {
+	if err != nil && r.StatusCode == 0 {
+		return true
+	}
+
+	switch r.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusTooManyRequests:
+		return r.RetryAfter != ""
+	default:
+		return false
+	}
+}
+
+// sleepBeforeRetry waits min(maxDelay, baseDelay*2^(attempt-1)) with full jitter, or returns
+// ctx.Err() if the context is done first.
+func sleepBeforeRetry(ctx context.Context, retry *retryConfig, attempt int) error {
+	delay := retry.baseDelay << (attempt - 1) //nolint:gosec
+	if delay > retry.maxDelay || delay <= 0 {
+		delay = retry.maxDelay
+	}
+
+	jittered := time.Duration(rand.Int63n(int64(delay) + 1)) //nolint:gosec
+
+	timer := time.NewTimer(jittered)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// WithRetry enables retrying the request up to maxAttempts times, sleeping
+// min(maxDelay, baseDelay*2^(attempt-1)) with full jitter between attempts. maxAttempts below 1
+// is treated as 1 so a misconfigured caller still gets a response instead of DoRequest's loop
+// never executing. The default behavior (no WithRetry) is a single attempt.
+func WithRetry(maxAttempts int, baseDelay, maxDelay time.Duration) Opt {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return func(o *options) {
+		o.retry = &retryConfig{
+			maxAttempts: maxAttempts,
+			baseDelay:   baseDelay,
+			maxDelay:    maxDelay,
+		}
+	}
+}
+
+// WithRetryOn overrides the default retry predicate (network errors, 502/503/504, and 429 with
+// a Retry-After header).
+func WithRetryOn(fn func(*Response, error) bool) Opt {
+	return func(o *options) {
+		o.retryOn = fn
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRetry_InvalidMaxAttemptsIsClampedToOne(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	for _, maxAttempts := range []int{0, -1} {
+		resp, err := DoRequest(context.Background(), server.URL,
+			WithBody([]byte{}), WithRetry(maxAttempts, time.Millisecond, time.Millisecond))
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, 1, resp.Attempts)
+	}
+}
+
+func TestDoRequest_RetriesOnServiceUnavailable(t *testing.T) {
+	attempts := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	resp, err := DoRequest(context.Background(), server.URL,
+		WithBody([]byte{}), WithRetry(5, time.Millisecond, 2*time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, 3, resp.Attempts)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
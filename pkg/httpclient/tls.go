@@ -0,0 +1,139 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// WithRootCAs specifies the root CA pool used to verify the server certificate.
+func WithRootCAs(pool *x509.CertPool) Opt {
+	return func(o *options) {
+		o.tlsConfig().RootCAs = pool
+	}
+}
+
+// WithClientCertificate configures mTLS client-certificate authentication using an in-memory
+// PEM-encoded certificate and private key. It refuses to silently ignore the certificate when the
+// caller has already supplied a *http.Client with its own TLSClientConfig via WithHTTPClient.
+func WithClientCertificate(certPEM, keyPEM []byte) Opt {
+	return func(o *options) {
+		if o.clientCertErr != nil {
+			return
+		}
+
+		if o.httpClientTLSConfigSet {
+			o.clientCertErr = fmt.Errorf("with client certificate: caller-supplied http client already sets TLSClientConfig")
+
+			return
+		}
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			o.clientCertErr = fmt.Errorf("parse client certificate: %w", err)
+
+			return
+		}
+
+		o.tlsConfig().Certificates = []tls.Certificate{cert}
+	}
+}
+
+// WithClientCertificateFromFiles is like WithClientCertificate but reads the certificate and key
+// from the given files via tls.Config.GetClientCertificate, a callback safe for concurrent use by
+// the TLS stack during a handshake. The underlying certificate is reloaded on SIGHUP so a
+// short-lived issued certificate can be rotated without restarting the process. The watcher
+// (goroutine + signal registration) behind a given certPath/keyPath pair is started at most once
+// per process, no matter how many times this option is applied.
+func WithClientCertificateFromFiles(certPath, keyPath string) Opt {
+	return func(o *options) {
+		if o.clientCertErr != nil {
+			return
+		}
+
+		if o.httpClientTLSConfigSet {
+			o.clientCertErr = fmt.Errorf("with client certificate: caller-supplied http client already sets TLSClientConfig")
+
+			return
+		}
+
+		watcher, err := getOrStartCertWatcher(certPath, keyPath)
+		if err != nil {
+			o.clientCertErr = fmt.Errorf("load client certificate: %w", err)
+
+			return
+		}
+
+		o.tlsConfig().GetClientCertificate = watcher.getClientCertificate
+	}
+}
+
+// certWatcher holds the current client certificate for a certPath/keyPath pair, reloaded on
+// SIGHUP. Reads and writes go through an atomic.Value so concurrent TLS handshakes calling
+// getClientCertificate never race with a reload.
+type certWatcher struct {
+	cert atomic.Value // tls.Certificate
+}
+
+func (w *certWatcher) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	cert, _ := w.cert.Load().(tls.Certificate)
+
+	return &cert, nil
+}
+
+var (
+	certWatchersMu sync.Mutex                  //nolint:gochecknoglobals
+	certWatchers   = map[string]*certWatcher{} //nolint:gochecknoglobals
+)
+
+// getOrStartCertWatcher returns the existing watcher for certPath/keyPath, or loads the
+// certificate, starts its SIGHUP reload goroutine, and registers a new one.
+func getOrStartCertWatcher(certPath, keyPath string) (*certWatcher, error) {
+	key := certPath + "|" + keyPath
+
+	certWatchersMu.Lock()
+	defer certWatchersMu.Unlock()
+
+	if w, ok := certWatchers[key]; ok {
+		return w, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &certWatcher{}
+	w.cert.Store(cert)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	go func() {
+		for range sigCh {
+			reloaded, reloadErr := tls.LoadX509KeyPair(certPath, keyPath)
+			if reloadErr != nil {
+				logger.Errorf("Failed to reload client certificate on SIGHUP: %s", reloadErr.Error())
+
+				continue
+			}
+
+			w.cert.Store(reloaded)
+		}
+	}()
+
+	certWatchers[key] = w
+
+	return w, nil
+}
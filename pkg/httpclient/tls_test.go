@@ -0,0 +1,106 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRootCAs_VerifiesServerCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	resp, err := DoRequest(context.Background(), server.URL, WithBody([]byte{}), WithRootCAs(pool))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestWithClientCertificateFromFiles_SendsClientCertificate(t *testing.T) {
+	clientCert, certPath, keyPath := writeTestClientCertFiles(t)
+
+	clientPool := x509.NewCertPool()
+	clientPool.AddCert(clientCert.Leaf)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NotEmpty(t, r.TLS.PeerCertificates, "server should have received a client certificate")
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientPool} //nolint:gosec
+	server.StartTLS()
+
+	defer server.Close()
+
+	serverPool := x509.NewCertPool()
+	serverPool.AddCert(server.Certificate())
+
+	resp, err := DoRequest(context.Background(), server.URL, WithBody([]byte{}),
+		WithRootCAs(serverPool), WithClientCertificateFromFiles(certPath, keyPath))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// writeTestClientCertFiles generates a self-signed certificate/key pair, writes them as PEM files
+// under a t.TempDir, and returns the parsed certificate (with Leaf set) alongside the file paths.
+func writeTestClientCertFiles(t *testing.T) (cert tls.Certificate, certPath, keyPath string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kms-httpclient-test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	dir := t.TempDir()
+	certPath = filepath.Join(dir, "client.crt")
+	keyPath = filepath.Join(dir, "client.key")
+
+	require.NoError(t, os.WriteFile(certPath, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyPath, keyPEM, 0o600))
+
+	cert, err = tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	parsedCert, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	cert.Leaf = parsedCert
+
+	return cert, certPath, keyPath
+}
@@ -0,0 +1,418 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package vault implements a secretlock.Service backed by HashiCorp Vault's
+// Transit secrets engine, so the master key used by the local KMS provider
+// can be wrapped/unwrapped by Vault instead of kept on disk or in AWS KMS.
+package vault
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/spi/secretlock"
+)
+
+var logger = log.New("kms-secretlock-vault") //nolint:gochecknoglobals
+
+const (
+	tokenHeader     = "X-Vault-Token"
+	namespaceHeader = "X-Vault-Namespace"
+
+	loginRetryAttempts = 5
+	loginRetryBaseWait = 500 * time.Millisecond
+
+	renewBeforeExpiry = 30 * time.Second
+)
+
+// Config configures a Vault Transit Service.
+type Config struct {
+	// Address is the base URL of the Vault server, e.g. "https://vault.example.com:8200".
+	Address string
+	// Namespace is an optional Vault Enterprise namespace.
+	Namespace string
+	// TransitMount is the mount path of the Transit secrets engine, e.g. "transit".
+	TransitMount string
+	// KeyName is the name of the Transit key used to wrap/unwrap the master key.
+	KeyName string
+
+	// Token is a pre-issued Vault token. If set, AppRole login is skipped.
+	Token string
+
+	// RoleID and SecretID are AppRole credentials used to log in to Vault when Token is empty.
+	RoleID   string
+	SecretID string
+
+	// HTTPClient is the HTTP client used to talk to Vault. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Service wraps/unwraps the master key via Vault's Transit engine.
+type Service struct {
+	cfg    Config
+	client *http.Client
+
+	mu         sync.RWMutex
+	token      string
+	stopRenew  chan struct{}
+	renewOnce  sync.Once
+}
+
+// New creates a Vault Transit Service, logging in via AppRole if cfg.Token is not set, and starts
+// a background goroutine that renews the token before it expires - for an AppRole-obtained token
+// it runs when the login returns a non-zero lease duration; for a pre-issued cfg.Token it runs if
+// the token's remaining TTL can be discovered via lookup-self (a TTL of zero, e.g. a root token, disables renewal).
+func New(cfg Config) (*Service, error) {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	s := &Service{
+		cfg:       cfg,
+		client:    client,
+		stopRenew: make(chan struct{}),
+	}
+
+	token := cfg.Token
+
+	var leaseDuration int
+
+	if token == "" {
+		var err error
+
+		token, leaseDuration, err = s.loginAppRoleWithRetry()
+		if err != nil {
+			return nil, fmt.Errorf("vault approle login: %w", err)
+		}
+
+		s.setToken(token)
+	} else {
+		s.setToken(token)
+
+		ttl, err := s.lookupSelf()
+		if err != nil {
+			logger.Errorf("vault token lookup-self failed, background renewal disabled: %s", err.Error())
+		} else {
+			leaseDuration = ttl
+		}
+	}
+
+	if leaseDuration > 0 {
+		go s.renewLoop(time.Duration(leaseDuration) * time.Second)
+	}
+
+	return s, nil
+}
+
+// Close stops the background token-renewal goroutine.
+func (s *Service) Close() {
+	s.renewOnce.Do(func() {
+		close(s.stopRenew)
+	})
+}
+
+// Encrypt wraps req.Plaintext using the Transit key named by the KeyName from Config (keyURI is
+// accepted to satisfy secretlock.Service and is otherwise unused).
+func (s *Service) Encrypt(keyURI string, req *secretlock.EncryptRequest) (*secretlock.EncryptResponse, error) {
+	resp, err := s.transitRequest("encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString([]byte(req.Plaintext)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vault encrypt: %w", err)
+	}
+
+	return &secretlock.EncryptResponse{Ciphertext: resp.Data.Ciphertext}, nil
+}
+
+// Decrypt unwraps req.Ciphertext using the Transit key named by the KeyName from Config (see
+// Encrypt for keyURI).
+func (s *Service) Decrypt(keyURI string, req *secretlock.DecryptRequest) (*secretlock.DecryptResponse, error) {
+	resp, err := s.transitRequest("decrypt", map[string]string{
+		"ciphertext": req.Ciphertext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vault decrypt: %w", err)
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("decode vault plaintext: %w", err)
+	}
+
+	return &secretlock.DecryptResponse{Plaintext: string(plaintext)}, nil
+}
+
+type transitResponse struct {
+	Data struct {
+		Ciphertext string `json:"ciphertext"`
+		Plaintext  string `json:"plaintext"`
+	} `json:"data"`
+}
+
+func (s *Service) transitRequest(op string, payload map[string]string) (*transitResponse, error) {
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", strings.TrimSuffix(s.cfg.Address, "/"), s.cfg.TransitMount, op, s.cfg.KeyName)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body)) //nolint:noctx
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(tokenHeader, s.getToken())
+
+	if s.cfg.Namespace != "" {
+		req.Header.Set(namespaceHeader, s.cfg.Namespace)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault transit request: %w", err)
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			logger.Errorf("failed to close response body: %s", closeErr.Error())
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault transit %s: unexpected status %s", op, resp.Status)
+	}
+
+	var out transitResponse
+
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode vault response: %w", err)
+	}
+
+	return &out, nil
+}
+
+func (s *Service) getToken() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.token
+}
+
+func (s *Service) setToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = token
+}
+
+type appRoleLoginResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+	} `json:"auth"`
+}
+
+func (s *Service) loginAppRole() (string, int, error) {
+	url := fmt.Sprintf("%s/v1/auth/approle/login", strings.TrimSuffix(s.cfg.Address, "/"))
+
+	payload, err := json.Marshal(map[string]string{
+		"role_id":   s.cfg.RoleID,
+		"secret_id": s.cfg.SecretID,
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("marshal approle login request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload)) //nolint:noctx
+	if err != nil {
+		return "", 0, fmt.Errorf("new approle login request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if s.cfg.Namespace != "" {
+		req.Header.Set(namespaceHeader, s.cfg.Namespace)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("approle login: %w", err)
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			logger.Errorf("failed to close response body: %s", closeErr.Error())
+		}
+	}()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return "", 0, fmt.Errorf("approle login: transient server error: %s", resp.Status)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("approle login: unexpected status %s", resp.Status)
+	}
+
+	var out appRoleLoginResponse
+
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", 0, fmt.Errorf("decode approle login response: %w", err)
+	}
+
+	return out.Auth.ClientToken, out.Auth.LeaseDuration, nil
+}
+
+// loginAppRoleWithRetry retries AppRole login with exponential backoff on any failure, transient
+// 5xx responses included, up to loginRetryAttempts attempts.
+func (s *Service) loginAppRoleWithRetry() (string, int, error) {
+	var lastErr error
+
+	wait := loginRetryBaseWait
+
+	for attempt := 1; attempt <= loginRetryAttempts; attempt++ {
+		token, leaseDuration, err := s.loginAppRole()
+		if err == nil {
+			return token, leaseDuration, nil
+		}
+
+		lastErr = err
+		logger.Warnf("vault approle login attempt %d/%d failed: %s", attempt, loginRetryAttempts, err.Error())
+
+		if attempt < loginRetryAttempts {
+			time.Sleep(wait)
+			wait *= 2
+		}
+	}
+
+	return "", 0, lastErr
+}
+
+// renewLoop runs in the background, renewing the current token shortly before its lease expires
+// via auth/token/renew-self, for as long as the Service is open. If renew-self fails and AppRole
+// credentials are configured, it falls back to a fresh AppRole login rather than continuing to
+// renew a token that may never recover (e.g. its max TTL was reached).
+func (s *Service) renewLoop(leaseDuration time.Duration) {
+	for {
+		sleep := leaseDuration - renewBeforeExpiry
+		if sleep <= 0 {
+			sleep = renewBeforeExpiry
+		}
+
+		select {
+		case <-time.After(sleep):
+		case <-s.stopRenew:
+			return
+		}
+
+		newLeaseDuration, err := s.renewSelf()
+		if err == nil {
+			leaseDuration = time.Duration(newLeaseDuration) * time.Second
+
+			continue
+		}
+
+		logger.Errorf("vault token renewal failed: %s", err.Error())
+
+		if s.cfg.RoleID == "" && s.cfg.SecretID == "" {
+			continue
+		}
+
+		token, newLeaseDuration, loginErr := s.loginAppRoleWithRetry()
+		if loginErr != nil {
+			logger.Errorf("vault approle re-login after failed renewal also failed: %s", loginErr.Error())
+
+			continue
+		}
+
+		s.setToken(token)
+		leaseDuration = time.Duration(newLeaseDuration) * time.Second
+	}
+}
+
+// lookupSelf returns the current token's remaining TTL in seconds via auth/token/lookup-self, so
+// a pre-issued (static) token can also be kept alive by renewLoop instead of only tokens obtained
+// via AppRole login.
+func (s *Service) lookupSelf() (int, error) {
+	url := fmt.Sprintf("%s/v1/auth/token/lookup-self", strings.TrimSuffix(s.cfg.Address, "/"))
+
+	req, err := http.NewRequest(http.MethodGet, url, nil) //nolint:noctx
+	if err != nil {
+		return 0, fmt.Errorf("new lookup-self request: %w", err)
+	}
+
+	req.Header.Set(tokenHeader, s.getToken())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("lookup-self: %w", err)
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			logger.Errorf("failed to close response body: %s", closeErr.Error())
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("lookup-self: unexpected status %s", resp.Status)
+	}
+
+	var out struct {
+		Data struct {
+			TTL int `json:"ttl"`
+		} `json:"data"`
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("decode lookup-self response: %w", err)
+	}
+
+	return out.Data.TTL, nil
+}
+
+func (s *Service) renewSelf() (int, error) {
+	url := fmt.Sprintf("%s/v1/auth/token/renew-self", strings.TrimSuffix(s.cfg.Address, "/"))
+
+	req, err := http.NewRequest(http.MethodPost, url, nil) //nolint:noctx
+	if err != nil {
+		return 0, fmt.Errorf("new renew-self request: %w", err)
+	}
+
+	req.Header.Set(tokenHeader, s.getToken())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("renew-self: %w", err)
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			logger.Errorf("failed to close response body: %s", closeErr.Error())
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("renew-self: unexpected status %s", resp.Status)
+	}
+
+	var out appRoleLoginResponse
+
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("decode renew-self response: %w", err)
+	}
+
+	return out.Auth.LeaseDuration, nil
+}
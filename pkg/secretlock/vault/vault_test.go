@@ -0,0 +1,191 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vault_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	dctest "github.com/ory/dockertest/v3"
+	dc "github.com/ory/dockertest/v3/docker"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/spi/secretlock"
+
+	"github.com/rajeshkalaria80/kms/pkg/secretlock/vault"
+)
+
+const (
+	vaultDevRootToken = "kms-dev-root-token" //nolint:gosec
+	transitMount      = "transit"
+	transitKeyName    = "kms-master-key"
+)
+
+func TestService_EncryptDecrypt(t *testing.T) {
+	pool, resource, addr := startVaultContainer(t)
+
+	defer func() {
+		require.NoError(t, pool.Purge(resource), "failed to purge Vault resource")
+	}()
+
+	enableTransit(t, addr)
+
+	svc, err := vault.New(vault.Config{
+		Address:      addr,
+		Token:        vaultDevRootToken,
+		TransitMount: transitMount,
+		KeyName:      transitKeyName,
+	})
+	require.NoError(t, err)
+
+	defer svc.Close()
+
+	plaintext := "top secret master key"
+
+	encResp, err := svc.Encrypt("", &secretlock.EncryptRequest{Plaintext: plaintext})
+	require.NoError(t, err)
+	require.NotEmpty(t, encResp.Ciphertext)
+
+	decResp, err := svc.Decrypt("", &secretlock.DecryptRequest{Ciphertext: encResp.Ciphertext})
+	require.NoError(t, err)
+	require.Equal(t, plaintext, decResp.Plaintext)
+}
+
+func TestService_AppRoleLogin(t *testing.T) {
+	pool, resource, addr := startVaultContainer(t)
+
+	defer func() {
+		require.NoError(t, pool.Purge(resource), "failed to purge Vault resource")
+	}()
+
+	enableTransit(t, addr)
+
+	roleID, secretID := enableAppRole(t, addr)
+
+	svc, err := vault.New(vault.Config{
+		Address:      addr,
+		RoleID:       roleID,
+		SecretID:     secretID,
+		TransitMount: transitMount,
+		KeyName:      transitKeyName,
+	})
+	require.NoError(t, err)
+
+	defer svc.Close()
+
+	plaintext := "top secret master key"
+
+	encResp, err := svc.Encrypt("", &secretlock.EncryptRequest{Plaintext: plaintext})
+	require.NoError(t, err)
+	require.NotEmpty(t, encResp.Ciphertext)
+
+	decResp, err := svc.Decrypt("", &secretlock.DecryptRequest{Ciphertext: encResp.Ciphertext})
+	require.NoError(t, err)
+	require.Equal(t, plaintext, decResp.Plaintext)
+}
+
+func startVaultContainer(t *testing.T) (*dctest.Pool, *dctest.Resource, string) {
+	t.Helper()
+
+	pool, err := dctest.NewPool("")
+	require.NoError(t, err)
+
+	resource, err := pool.RunWithOptions(&dctest.RunOptions{
+		Repository: "vault",
+		Tag:        "1.13",
+		Env:        []string{"VAULT_DEV_ROOT_TOKEN_ID=" + vaultDevRootToken},
+		PortBindings: map[dc.Port][]dc.PortBinding{
+			"8200/tcp": {{HostIP: "", HostPort: "8200"}},
+		},
+	})
+	require.NoError(t, err)
+
+	addr := "http://localhost:8200"
+
+	require.NoError(t, pool.Retry(func() error {
+		resp, httpErr := http.Get(addr + "/v1/sys/health") //nolint:noctx
+		if httpErr != nil {
+			return httpErr
+		}
+		defer resp.Body.Close() //nolint:errcheck
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("vault not ready: %s", resp.Status)
+		}
+
+		return nil
+	}))
+
+	return pool, resource, addr
+}
+
+func enableTransit(t *testing.T, addr string) {
+	t.Helper()
+
+	mount(t, addr, "/v1/sys/mounts/"+transitMount, `{"type":"transit"}`)
+	mount(t, addr, "/v1/"+transitMount+"/keys/"+transitKeyName, `{}`)
+}
+
+// enableAppRole enables the AppRole auth method, creates a role granting access to the Transit
+// key under test, and returns a role ID/secret ID pair that logs in as that role.
+func enableAppRole(t *testing.T, addr string) (roleID, secretID string) {
+	t.Helper()
+
+	mount(t, addr, "/v1/sys/auth/approle", `{"type":"approle"}`)
+	mount(t, addr, "/v1/sys/policies/acl/kms-transit",
+		`{"policy":"path \"`+transitMount+`/*\" { capabilities = [\"update\"] }"}`)
+	mount(t, addr, "/v1/auth/approle/role/kms",
+		`{"token_ttl":"30m","token_max_ttl":"60m","token_policies":"kms-transit"}`)
+
+	return readField(t, addr, http.MethodGet, "/v1/auth/approle/role/kms/role-id", "role_id"),
+		readField(t, addr, http.MethodPost, "/v1/auth/approle/role/kms/secret-id", "secret_id")
+}
+
+func readField(t *testing.T, addr, method, path, field string) string {
+	t.Helper()
+
+	req, err := http.NewRequest(method, addr+path, nil) //nolint:noctx
+	require.NoError(t, err)
+
+	req.Header.Set("X-Vault-Token", vaultDevRootToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var out struct {
+		Data map[string]interface{} `json:"data"`
+	}
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+
+	value, _ := out.Data[field].(string)
+
+	return value
+}
+
+func mount(t *testing.T, addr, path, body string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, addr+path, bytes.NewBufferString(body)) //nolint:noctx
+	require.NoError(t, err)
+
+	req.Header.Set("X-Vault-Token", vaultDevRootToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Less(t, resp.StatusCode, http.StatusInternalServerError)
+}
@@ -4,161 +4,116 @@ Copyright SecureKey Technologies Inc. All Rights Reserved.
 SPDX-License-Identifier: Apache-2.0
 */
 
+// Package httputil is a thin BDD-test wrapper around pkg/httpclient, the production outbound
+// HTTP client used by kms-server. It exists only so existing BDD step definitions can keep
+// importing "httputil" without changing call sites; it adds no behavior of its own.
 package httputil
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"io"
+	"crypto/x509"
 	"net/http"
+	"time"
 
-	"github.com/hyperledger/aries-framework-go/pkg/common/log"
-)
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 
-const (
-	contentType     = "Content-Type"
-	applicationJSON = "application/json"
-	authorization   = "Authorization"
+	"github.com/rajeshkalaria80/kms/pkg/httpclient"
 )
 
-var logger = log.New("kms-bdd")
-
 // Response is an HTTP response.
-type Response struct {
-	Status       string
-	StatusCode   int
-	Body         []byte
-	ErrorMessage string
-}
-
-// DoRequest makes an HTTP request.
-func DoRequest(ctx context.Context, url string, opts ...Opt) (*Response, error) { //nolint:funlen
-	op := &options{
-		httpClient: http.DefaultClient,
-		method:     http.MethodGet,
-	}
-
-	for _, fn := range opts {
-		fn(op)
-	}
-
-	body, err := io.ReadAll(op.body)
-	if err != nil {
-		return nil, fmt.Errorf("request body: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, op.method, url, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("new request: %w", err)
-	}
-
-	req.Header.Add(contentType, applicationJSON)
-
-	if op.gnapToken != "" {
-		req.Header.Add(authorization, "GNAP "+op.gnapToken)
-	}
+type Response = httpclient.Response
 
-	if op.signer != nil {
-		if err = op.signer.Sign(req); err != nil {
-			return nil, fmt.Errorf("sign http request: %w", err)
-		}
-
-		// recreate request with body again as Sign() above consumes the request Body reader.
-		req.Body = io.NopCloser(bytes.NewReader(body))
-	}
+// Opt configures HTTP request options.
+type Opt = httpclient.Opt
 
-	resp, err := op.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("http do: %w", err)
-	}
+// SigV4Config configures a SigV4Signer.
+type SigV4Config = httpclient.SigV4Config
 
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			logger.Errorf("Failed to close response body: %s\n", closeErr.Error())
-		}
-	}()
+// SigV4Signer signs HTTP requests using the AWS Signature Version 4 scheme.
+type SigV4Signer = httpclient.SigV4Signer
 
-	r := &Response{
-		Status:     resp.Status,
-		StatusCode: resp.StatusCode,
-	}
+// DoRequest makes an HTTP request.
+func DoRequest(ctx context.Context, url string, opts ...Opt) (*Response, error) {
+	return httpclient.DoRequest(ctx, url, opts...)
+}
 
-	body, err = io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
+// NewSigV4Signer creates a SigV4Signer from the given config.
+func NewSigV4Signer(cfg SigV4Config) *SigV4Signer {
+	return httpclient.NewSigV4Signer(cfg)
+}
 
-	if len(body) > 0 {
-		r.Body = body
+// WithHTTPClient specifies the custom HTTP client.
+func WithHTTPClient(c *http.Client) Opt {
+	return httpclient.WithHTTPClient(c)
+}
 
-		if resp.StatusCode != http.StatusOK {
-			var errResp errorResponse
+// WithMethod specifies an HTTP method. Default is GET.
+func WithMethod(method string) Opt {
+	return httpclient.WithMethod(method)
+}
 
-			if err = json.Unmarshal(body, &errResp); err == nil && errResp.Message != "" {
-				return nil, errors.New(errResp.Message)
-			}
+// WithBody specifies HTTP request body.
+func WithBody(val []byte) Opt {
+	return httpclient.WithBody(val)
+}
 
-			return nil, errors.New(resp.Status)
-		}
-	}
+// WithGNAPToken specifies an authorization GNAP token.
+func WithGNAPToken(token string) Opt {
+	return httpclient.WithGNAPToken(token)
+}
 
-	return r, nil
+// WithSigner specifies a request signer for HTTP Signatures.
+func WithSigner(signer interface{ Sign(req *http.Request) error }) Opt {
+	return httpclient.WithSigner(signer)
 }
 
-type errorResponse struct {
-	Message string `json:"errMessage,omitempty"`
+// WithAWSSigV4 specifies a request signer that computes AWS Signature Version 4 authorization
+// headers using the given credentials.
+func WithAWSSigV4(cfg SigV4Config) Opt {
+	return httpclient.WithAWSSigV4(cfg)
 }
 
-type requestSigner interface {
-	Sign(req *http.Request) error
+// WithRootCAs specifies the root CA pool used to verify the server certificate.
+func WithRootCAs(pool *x509.CertPool) Opt {
+	return httpclient.WithRootCAs(pool)
 }
 
-type options struct {
-	httpClient     *http.Client
-	method         string
-	headers        []string
-	body           io.Reader
-	gnapToken      string
-	signer         requestSigner
+// WithClientCertificate configures mTLS client-certificate authentication using an in-memory
+// PEM-encoded certificate and private key.
+func WithClientCertificate(certPEM, keyPEM []byte) Opt {
+	return httpclient.WithClientCertificate(certPEM, keyPEM)
 }
 
-// Opt configures HTTP request options.
-type Opt func(*options)
+// WithClientCertificateFromFiles is like WithClientCertificate but reads the certificate and key
+// from the given files, reloading them on SIGHUP.
+func WithClientCertificateFromFiles(certPath, keyPath string) Opt {
+	return httpclient.WithClientCertificateFromFiles(certPath, keyPath)
+}
 
-// WithHTTPClient specifies the custom HTTP client.
-func WithHTTPClient(c *http.Client) Opt {
-	return func(o *options) {
-		o.httpClient = c
-	}
+// WithRetry enables retrying the request up to maxAttempts times with exponential backoff and
+// full jitter.
+func WithRetry(maxAttempts int, baseDelay, maxDelay time.Duration) Opt {
+	return httpclient.WithRetry(maxAttempts, baseDelay, maxDelay)
 }
 
-// WithMethod specifies an HTTP method. Default is GET.
-func WithMethod(method string) Opt {
-	return func(o *options) {
-		o.method = method
-	}
+// WithRetryOn overrides the default retry predicate.
+func WithRetryOn(fn func(*Response, error) bool) Opt {
+	return httpclient.WithRetryOn(fn)
 }
 
-// WithBody specifies HTTP request body.
-func WithBody(val []byte) Opt {
-	return func(o *options) {
-		o.body = bytes.NewBuffer(val)
-	}
+// WithTracer enables tracing of outbound requests.
+func WithTracer(tp trace.TracerProvider) Opt {
+	return httpclient.WithTracer(tp)
 }
 
-// WithGNAPToken specifies an authorization GNAP token.
-func WithGNAPToken(token string) Opt {
-	return func(o *options) {
-		o.gnapToken = token
-	}
+// WithMeter enables recording request duration and payload size metrics.
+func WithMeter(mp metric.MeterProvider) Opt {
+	return httpclient.WithMeter(mp)
 }
 
-// WithSigner specifies a request signer for HTTP Signatures.
-func WithSigner(signer requestSigner) Opt {
-	return func(o *options) {
-		o.signer = signer
-	}
+// WithImpersonation sets delegated-identity headers so a downstream service can act on behalf of
+// sub instead of the caller's own identity.
+func WithImpersonation(sub string, extra map[string][]string) Opt {
+	return httpclient.WithImpersonation(sub, extra)
 }